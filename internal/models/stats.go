@@ -20,4 +20,14 @@ type Stat struct {
 	Status      string       `json:"status"`
 	CurrentIP   string       `json:"current_ip"`
 	PreviousIPs []netip.Addr `json:"previous_ips"`
+	// Records holds one sub-row per underlying DNS record for providers
+	// that reconcile more than one record for the same domain. It is
+	// left empty for providers that only ever manage a single record.
+	Records []StatRecord `json:"records,omitempty"`
+}
+
+// StatRecord is a sub-row of Stat describing a single DNS record.
+type StatRecord struct {
+	IP     netip.Addr `json:"ip"`
+	Status string     `json:"status"`
 }
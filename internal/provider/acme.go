@@ -0,0 +1,17 @@
+package provider
+
+import "context"
+
+// ACMETXTProvider is an optional interface a Provider can implement to be
+// usable as an ACME DNS-01 challenge solver. It is satisfied by providers
+// capable of creating and removing arbitrary TXT records for a domain they
+// already manage, such as cloudflare.
+type ACMETXTProvider interface {
+	// PresentTXT creates the TXT record requested by an ACME DNS-01
+	// challenge (fqdn is usually of the form `_acme-challenge.<domain>`)
+	// and should only return once the record has propagated.
+	PresentTXT(ctx context.Context, fqdn, value string) (err error)
+	// CleanupTXT removes the TXT record created by a previous call to
+	// PresentTXT for the same fqdn and value.
+	CleanupTXT(ctx context.Context, fqdn, value string) (err error)
+}
@@ -0,0 +1,146 @@
+package cloudflare
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"time"
+
+	cf "github.com/cloudflare/cloudflare-go/v6"
+	cfdns "github.com/cloudflare/cloudflare-go/v6/dns"
+)
+
+// Default propagation polling interval and timeout PresentTXT uses to wait
+// for the challenge TXT record to become visible before returning, used
+// unless overridden by the acme_propagation_poll_seconds and
+// acme_propagation_timeout_seconds settings.
+const (
+	defaultACMEPropagationPoll    = 2 * time.Second
+	defaultACMEPropagationTimeout = 120 * time.Second
+	acmeRecordTTL                 = 120
+)
+
+var errTXTRecordPropagationTimedOut = stderrors.New("TXT record did not propagate within the allowed time")
+
+// acmeChallengeKey identifies one in-flight ACME DNS-01 challenge, allowing
+// several challenges for the same fqdn (e.g. a wildcard and apex SAN) to be
+// tracked independently.
+type acmeChallengeKey struct {
+	fqdn  string
+	value string
+}
+
+// PresentTXT implements provider.ACMETXTProvider. It creates the
+// `_acme-challenge.<domain>` TXT record expected by the ACME DNS-01 flow and
+// blocks until it can be resolved, so the caller can safely request the
+// certificate authority to verify the challenge right after this returns.
+func (p *Provider) PresentTXT(ctx context.Context, fqdn, value string) (err error) {
+	key := acmeChallengeKey{fqdn: fqdn, value: value}
+
+	// A retried call for a challenge already presented (e.g. the caller
+	// timed out client-side while propagation was still in flight) must
+	// not create a second TXT record that CleanupTXT would never find.
+	p.acmeMutex.Lock()
+	_, alreadyPresented := p.acmeRecordIDs[key]
+	p.acmeMutex.Unlock()
+
+	if !alreadyPresented {
+		cfClient, err := p.createCloudflareClient()
+		if err != nil {
+			return fmt.Errorf("creating cloudflare client: %w", err)
+		}
+
+		zoneID, err := p.resolveZoneID(ctx, cfClient)
+		if err != nil {
+			return fmt.Errorf("resolving zone: %w", err)
+		}
+
+		result, err := cfClient.DNS.Records.New(ctx, cfdns.RecordNewParams{
+			ZoneID: cf.F(zoneID),
+			Body: cfdns.TXTRecordParam{
+				Name:    cf.F(fqdn),
+				Type:    cf.F(cfdns.TXTRecordTypeTXT),
+				Content: cf.F(value),
+				TTL:     cf.F(cfdns.TTL(acmeRecordTTL)),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("creating TXT record: %w", err)
+		}
+
+		p.acmeMutex.Lock()
+		if p.acmeRecordIDs == nil {
+			p.acmeRecordIDs = make(map[acmeChallengeKey]string)
+		}
+		p.acmeRecordIDs[key] = result.ID
+		p.acmeMutex.Unlock()
+	}
+
+	return p.waitForTXTPropagation(ctx, fqdn, value)
+}
+
+// CleanupTXT implements provider.ACMETXTProvider. It removes the TXT record
+// created by the matching PresentTXT call for the same fqdn and value, and
+// is a no-op if no such record is tracked (e.g. cleanup called twice).
+func (p *Provider) CleanupTXT(ctx context.Context, fqdn, value string) (err error) {
+	key := acmeChallengeKey{fqdn: fqdn, value: value}
+
+	p.acmeMutex.Lock()
+	recordID, ok := p.acmeRecordIDs[key]
+	if ok {
+		delete(p.acmeRecordIDs, key)
+	}
+	p.acmeMutex.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	cfClient, err := p.createCloudflareClient()
+	if err != nil {
+		return fmt.Errorf("creating cloudflare client: %w", err)
+	}
+
+	zoneID, err := p.resolveZoneID(ctx, cfClient)
+	if err != nil {
+		return fmt.Errorf("resolving zone: %w", err)
+	}
+
+	_, err = cfClient.DNS.Records.Delete(ctx, recordID, cfdns.RecordDeleteParams{
+		ZoneID: cf.F(zoneID),
+	})
+	if err != nil {
+		return fmt.Errorf("deleting TXT record: %w", err)
+	}
+	return nil
+}
+
+// waitForTXTPropagation polls fqdn until value shows up in its TXT records
+// or p.acmePropagationTimeout elapses.
+func (p *Provider) waitForTXTPropagation(ctx context.Context, fqdn, value string) (err error) {
+	deadline := time.Now().Add(p.acmePropagationTimeout)
+	ticker := time.NewTicker(p.acmePropagationPoll)
+	defer ticker.Stop()
+
+	for {
+		txtRecords, lookupErr := net.DefaultResolver.LookupTXT(ctx, fqdn)
+		if lookupErr == nil {
+			for _, txtRecord := range txtRecords {
+				if txtRecord == value {
+					return nil
+				}
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s", errTXTRecordPropagationTimedOut, fqdn)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
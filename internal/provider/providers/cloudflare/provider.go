@@ -9,11 +9,18 @@ import (
 	"net/http"
 	"net/netip"
 	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	cf "github.com/cloudflare/cloudflare-go/v6"
 	cfdns "github.com/cloudflare/cloudflare-go/v6/dns"
 	"github.com/cloudflare/cloudflare-go/v6/option"
+	cfzones "github.com/cloudflare/cloudflare-go/v6/zones"
+	"golang.org/x/net/publicsuffix"
+
 	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/provider"
 	"github.com/qdm12/ddns-updater/internal/provider/constants"
 	"github.com/qdm12/ddns-updater/internal/provider/errors"
 	"github.com/qdm12/ddns-updater/internal/provider/utils"
@@ -30,8 +37,32 @@ type Provider struct {
 	email          string
 	userServiceKey string
 	zoneIdentifier string
-	proxied        bool
+	zoneMutex      sync.Mutex // guards zoneIdentifier when it is lazily resolved
+	sslMutex       sync.Mutex // guards fullSSLEnsured
+	fullSSLEnsured bool       // true once the zone's SSL mode is known to be "full"
+	accountID      string
+	proxyMode      ProxyMode
+	mode           Mode
 	ttl            uint32
+
+	// acmePropagationPoll and acmePropagationTimeout configure how long
+	// PresentTXT waits for the challenge TXT record to propagate before
+	// giving up, polling at the former interval until the latter elapses.
+	acmePropagationPoll    time.Duration
+	acmePropagationTimeout time.Duration
+
+	// acmeMutex guards acmeRecordIDs, which tracks the DNS record ID
+	// created for each in-flight ACME DNS-01 challenge so CleanupTXT
+	// can remove the right record even when several challenges for the
+	// same fqdn are presented concurrently.
+	acmeMutex     sync.Mutex
+	acmeRecordIDs map[acmeChallengeKey]string
+
+	// statusMutex guards recordStatuses, the last known per-record state
+	// computed by reconcileRecords for the append/replace modes, so
+	// RecordStatuses can be read concurrently with an Update call.
+	statusMutex    sync.Mutex
+	recordStatuses []provider.RecordStatus
 }
 
 func New(data json.RawMessage, domain, owner string,
@@ -44,32 +75,63 @@ func New(data json.RawMessage, domain, owner string,
 		Email          string `json:"email"`
 		UserServiceKey string `json:"user_service_key"`
 		ZoneIdentifier string `json:"zone_identifier"`
-		Proxied        bool   `json:"proxied"`
+		AccountID      string `json:"account_id"`
+		Proxy          string `json:"proxy"`
+		Proxied        bool   `json:"proxied"` // deprecated in favour of Proxy, kept for backward compatibility
+		Mode           string `json:"mode"`
 		TTL            uint32 `json:"ttl"`
+		// AcmePropagationPollSeconds and AcmePropagationTimeoutSeconds
+		// configure PresentTXT's propagation wait; they default to 2s
+		// and 120s respectively when left at zero.
+		AcmePropagationPollSeconds    uint32 `json:"acme_propagation_poll_seconds"`
+		AcmePropagationTimeoutSeconds uint32 `json:"acme_propagation_timeout_seconds"`
 	}{}
 	err = json.Unmarshal(data, &extraSettings)
 	if err != nil {
 		return nil, err
 	}
 
-	err = validateSettings(domain, extraSettings.Email, extraSettings.Key, extraSettings.UserServiceKey,
-		extraSettings.ZoneIdentifier, extraSettings.TTL)
+	err = validateSettings(domain, extraSettings.Email, extraSettings.Key, extraSettings.Token,
+		extraSettings.UserServiceKey, extraSettings.ZoneIdentifier, extraSettings.AccountID, extraSettings.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	proxyMode, err := parseProxyMode(extraSettings.Proxy, extraSettings.Proxied)
 	if err != nil {
 		return nil, fmt.Errorf("validating provider specific settings: %w", err)
 	}
 
+	mode, err := parseMode(extraSettings.Mode)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	acmePropagationPoll := defaultACMEPropagationPoll
+	if extraSettings.AcmePropagationPollSeconds > 0 {
+		acmePropagationPoll = time.Duration(extraSettings.AcmePropagationPollSeconds) * time.Second
+	}
+	acmePropagationTimeout := defaultACMEPropagationTimeout
+	if extraSettings.AcmePropagationTimeoutSeconds > 0 {
+		acmePropagationTimeout = time.Duration(extraSettings.AcmePropagationTimeoutSeconds) * time.Second
+	}
+
 	return &Provider{
-		domain:         domain,
-		owner:          owner,
-		ipVersion:      ipVersion,
-		ipv6Suffix:     ipv6Suffix,
-		key:            extraSettings.Key,
-		token:          extraSettings.Token,
-		email:          extraSettings.Email,
-		userServiceKey: extraSettings.UserServiceKey,
-		zoneIdentifier: extraSettings.ZoneIdentifier,
-		proxied:        extraSettings.Proxied,
-		ttl:            extraSettings.TTL,
+		domain:                 domain,
+		owner:                  owner,
+		ipVersion:              ipVersion,
+		ipv6Suffix:             ipv6Suffix,
+		key:                    extraSettings.Key,
+		token:                  extraSettings.Token,
+		email:                  extraSettings.Email,
+		userServiceKey:         extraSettings.UserServiceKey,
+		zoneIdentifier:         extraSettings.ZoneIdentifier,
+		accountID:              extraSettings.AccountID,
+		proxyMode:              proxyMode,
+		mode:                   mode,
+		ttl:                    extraSettings.TTL,
+		acmePropagationPoll:    acmePropagationPoll,
+		acmePropagationTimeout: acmePropagationTimeout,
 	}, nil
 }
 
@@ -77,9 +139,12 @@ var (
 	keyRegex            = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
 	userServiceKeyRegex = regexp.MustCompile(`^v1\.0.+$`)
 	regexEmail          = regexp.MustCompile(`[a-zA-Z0-9-_.+]+@[a-zA-Z0-9-_.]+\.[a-zA-Z]{2,10}`)
+	accountIDRegex      = regexp.MustCompile(`^[a-f0-9]{32}$`)
 )
 
-func validateSettings(domain, email, key, userServiceKey, zoneIdentifier string, ttl uint32) (err error) {
+var errAccountIDNotValid = stderrors.New("account id is not valid")
+
+func validateSettings(domain, email, key, token, userServiceKey, zoneIdentifier, accountID string, ttl uint32) (err error) {
 	err = utils.CheckDomain(domain)
 	if err != nil {
 		return fmt.Errorf("%w: %w", errors.ErrDomainNotValid, err)
@@ -102,10 +167,18 @@ func validateSettings(domain, email, key, userServiceKey, zoneIdentifier string,
 		}
 	default: // constants.API token only
 	}
-	switch {
-	case zoneIdentifier == "":
+
+	// The zone identifier can be left unset when a token is used: the
+	// token's own permissions already scope which zones are visible, so
+	// resolveZoneID can safely look it up from the domain on first use.
+	if zoneIdentifier == "" && token == "" {
 		return fmt.Errorf("%w", errors.ErrZoneIdentifierNotSet)
-	case ttl == 0:
+	}
+	if accountID != "" && !accountIDRegex.MatchString(accountID) {
+		return fmt.Errorf("%w: account id %q does not match regex %q",
+			errAccountIDNotValid, accountID, accountIDRegex)
+	}
+	if ttl == 0 {
 		return fmt.Errorf("%w", errors.ErrTTLNotSet)
 	}
 	return nil
@@ -132,7 +205,7 @@ func (p *Provider) IPv6Suffix() netip.Prefix {
 }
 
 func (p *Provider) Proxied() bool {
-	return p.proxied
+	return p.proxyMode.Proxied()
 }
 
 func (p *Provider) BuildDomainName() string {
@@ -140,36 +213,109 @@ func (p *Provider) BuildDomainName() string {
 }
 
 func (p *Provider) HTML() models.HTMLRow {
+	providerLabel := "<a href=\"https://www.cloudflare.com\">Cloudflare</a>"
+	if p.proxyMode != ProxyOff {
+		providerLabel += fmt.Sprintf(" (proxy: %s)", p.proxyMode)
+	}
 	return models.HTMLRow{
 		Domain:    fmt.Sprintf("<a href=\"http://%s\">%s</a>", p.BuildDomainName(), p.BuildDomainName()),
 		Owner:     p.Owner(),
-		Provider:  "<a href=\"https://www.cloudflare.com\">Cloudflare</a>",
+		Provider:  providerLabel,
 		IPVersion: p.ipVersion.String(),
 	}
 }
 
 // createCloudflareClient 创建Cloudflare API客户端.
 func (p *Provider) createCloudflareClient() (*cf.Client, error) {
-	if p.token != "" {
+	options := make([]option.RequestOption, 0, 2) //nolint:mnd
+
+	switch {
+	case p.token != "":
 		// 使用 API token
-		return cf.NewClient(option.WithAPIToken(p.token)), nil
-	} else if p.email != "" && p.key != "" {
+		options = append(options, option.WithAPIToken(p.token))
+	case p.email != "" && p.key != "":
 		// 使用 email + API key
-		return cf.NewClient(option.WithAPIKey(p.key), option.WithAPIEmail(p.email)), nil
-	} else if p.userServiceKey != "" {
+		options = append(options, option.WithAPIKey(p.key), option.WithAPIEmail(p.email))
+	case p.userServiceKey != "":
 		// 使用 user service key
-		return cf.NewClient(option.WithAPIKey(p.userServiceKey)), nil
+		options = append(options, option.WithAPIKey(p.userServiceKey))
+	default:
+		return nil, fmt.Errorf("no authentication method available")
 	}
-	return nil, fmt.Errorf("no authentication method available")
+
+	return cf.NewClient(options...), nil
+}
+
+// resolveZoneID returns the provider's zone identifier, resolving and
+// caching it from p.domain if it was not supplied in the settings. Parent
+// domains are tried label by label down to (and including) the registrable
+// domain, i.e. the public suffix plus one label: for `a.b.example.co.uk`,
+// whose public suffix is `co.uk`, it tries `a.b.example.co.uk`, then
+// `b.example.co.uk`, then `example.co.uk`, and stops there rather than
+// probing the bare public suffix `co.uk` itself. When p.accountID is set,
+// only zones belonging to that account are considered.
+func (p *Provider) resolveZoneID(ctx context.Context, cfClient *cf.Client) (zoneID string, err error) {
+	p.zoneMutex.Lock()
+	defer p.zoneMutex.Unlock()
+
+	if p.zoneIdentifier != "" {
+		return p.zoneIdentifier, nil
+	}
+
+	candidates, err := zoneCandidates(p.domain)
+	if err != nil {
+		return "", err
+	}
+
+	listParams := cfzones.ZoneListParams{}
+	if p.accountID != "" {
+		listParams.AccountID = cf.F(p.accountID)
+	}
+
+	for _, candidate := range candidates {
+		listParams.Name = cf.F(candidate)
+		zones, err := cfClient.Zones.List(ctx, listParams)
+		if err != nil {
+			return "", fmt.Errorf("listing zones for %q: %w", candidate, err)
+		}
+		if len(zones.Result) > 0 {
+			p.zoneIdentifier = zones.Result[0].ID
+			return p.zoneIdentifier, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no zone found for domain %q", errors.ErrZoneIdentifierNotSet, p.domain)
+}
+
+// zoneCandidates returns the zone-name candidates resolveZoneID should try
+// for domain, from most to least specific: domain itself, then each of its
+// parent domains down to (and including) the registrable domain, i.e. the
+// public suffix plus one label. For `a.b.example.co.uk`, whose public
+// suffix is `co.uk`, it returns `a.b.example.co.uk`, `b.example.co.uk` and
+// `example.co.uk`, stopping there rather than also probing the bare public
+// suffix `co.uk` itself.
+func zoneCandidates(domain string) (candidates []string, err error) {
+	registrableDomain, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return nil, fmt.Errorf("determining registrable domain for %q: %w", domain, err)
+	}
+	minLabels := len(strings.Split(registrableDomain, "."))
+
+	labels := strings.Split(domain, ".")
+	candidates = make([]string, 0, len(labels)-minLabels+1)
+	for i := 0; i < len(labels)-minLabels+1; i++ {
+		candidates = append(candidates, strings.Join(labels[i:], "."))
+	}
+	return candidates, nil
 }
 
 // Obtain domain ID.
-func (p *Provider) getRecordID(ctx context.Context, cfClient *cf.Client, newIP netip.Addr) (
+func (p *Provider) getRecordID(ctx context.Context, cfClient *cf.Client, zoneID string, newIP netip.Addr) (
 	identifier string, upToDate bool, err error,
 ) {
 	// 获取 DNS 记录列表
 	records, err := cfClient.DNS.Records.List(ctx, cfdns.RecordListParams{
-		ZoneID: cf.F(p.zoneIdentifier),
+		ZoneID: cf.F(zoneID),
 		Type:   cf.F(cfdns.RecordListParamsType(recordTypeForIP(newIP))),
 		Name: cf.F(cfdns.RecordListParamsName{
 			Exact: cf.F(p.BuildDomainName()),
@@ -191,16 +337,18 @@ func (p *Provider) getRecordID(ctx context.Context, cfClient *cf.Client, newIP n
 	return records.Result[0].ID, false, nil
 }
 
-func (p *Provider) createRecord(ctx context.Context, cfClinet *cf.Client, ip netip.Addr) (recordID string, err error) {
+func (p *Provider) createRecord(ctx context.Context, cfClinet *cf.Client, zoneID string, ip netip.Addr) (
+	recordID string, err error,
+) {
 	// 创建新的 DNS 记录
 	result, err := cfClinet.DNS.Records.New(ctx, cfdns.RecordNewParams{
-		ZoneID: cf.F(p.zoneIdentifier),
+		ZoneID: cf.F(zoneID),
 		Body: cfdns.ARecordParam{
 			Name:    cf.F(p.BuildDomainName()),
 			Type:    cf.F(recordTypeForIP(ip)),
 			Content: cf.F(ip.String()),
 			TTL:     cf.F(cfdns.TTL(p.ttl)),
-			Proxied: cf.F(p.proxied),
+			Proxied: cf.F(p.proxyMode.Proxied()),
 			// Settings: cf.F(cfdns.ARecordSettingsParam{
 			// 	IPV4Only: cf.F(p.ipVersion == ipversion.IP4),
 			// 	IPV6Only: cf.F(p.ipVersion == ipversion.IP6),
@@ -218,10 +366,29 @@ func (p *Provider) Update(ctx context.Context, _ *http.Client, ip netip.Addr) (n
 	if err != nil {
 		return netip.Addr{}, fmt.Errorf("failed to create cloudflare client: %w", err)
 	}
-	dnsRecordID, upToDate, err := p.getRecordID(ctx, cfClient, ip)
+
+	zoneID, err := p.resolveZoneID(ctx, cfClient)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("resolving zone: %w", err)
+	}
+
+	if p.proxyMode == ProxyFull {
+		if err := p.ensureFullSSL(ctx, cfClient, zoneID); err != nil {
+			return netip.Addr{}, fmt.Errorf("enabling full proxy: %w", err)
+		}
+	}
+
+	if p.mode != ModeSingle {
+		if err := p.reconcileRecords(ctx, cfClient, zoneID, ip); err != nil {
+			return netip.Addr{}, fmt.Errorf("reconciling records: %w", err)
+		}
+		return ip, nil
+	}
+
+	dnsRecordID, upToDate, err := p.getRecordID(ctx, cfClient, zoneID, ip)
 	switch {
 	case stderrors.Is(err, errors.ErrReceivedNoResult):
-		dnsRecordID, err = p.createRecord(ctx, cfClient, ip)
+		dnsRecordID, err = p.createRecord(ctx, cfClient, zoneID, ip)
 		if err != nil {
 			return netip.Addr{}, fmt.Errorf("creating record: %w", err)
 		}
@@ -232,13 +399,13 @@ func (p *Provider) Update(ctx context.Context, _ *http.Client, ip netip.Addr) (n
 	}
 
 	_, err = cfClient.DNS.Records.Update(ctx, dnsRecordID, cfdns.RecordUpdateParams{
-		ZoneID: cf.F(p.zoneIdentifier),
+		ZoneID: cf.F(zoneID),
 		Body: cfdns.ARecordParam{
 			Name:    cf.F(p.BuildDomainName()),
 			Type:    cf.F(recordTypeForIP(ip)),
 			Content: cf.F(ip.String()),
 			TTL:     cf.F(cfdns.TTL(p.ttl)),
-			Proxied: cf.F(p.proxied),
+			Proxied: cf.F(p.proxyMode.Proxied()),
 		},
 	})
 	if err != nil {
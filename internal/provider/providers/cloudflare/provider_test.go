@@ -0,0 +1,58 @@
+package cloudflare
+
+import "testing"
+
+func Test_zoneCandidates(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		domain     string
+		candidates []string
+		wantErr    bool
+	}{
+		"apex domain": {
+			domain:     "example.com",
+			candidates: []string{"example.com"},
+		},
+		"single subdomain": {
+			domain:     "sub.example.com",
+			candidates: []string{"sub.example.com", "example.com"},
+		},
+		"multiple subdomains over a multi-label public suffix": {
+			domain:     "a.b.example.co.uk",
+			candidates: []string{"a.b.example.co.uk", "b.example.co.uk", "example.co.uk"},
+		},
+		"bare public suffix is rejected": {
+			domain:  "co.uk",
+			wantErr: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			candidates, err := zoneCandidates(testCase.domain)
+
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+			if len(candidates) != len(testCase.candidates) {
+				t.Fatalf("expected candidates %v, got %v", testCase.candidates, candidates)
+			}
+			for i, candidate := range candidates {
+				if candidate != testCase.candidates[i] {
+					t.Fatalf("expected candidates %v, got %v", testCase.candidates, candidates)
+				}
+			}
+		})
+	}
+}
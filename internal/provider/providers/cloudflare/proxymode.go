@@ -0,0 +1,86 @@
+package cloudflare
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	cf "github.com/cloudflare/cloudflare-go/v6"
+	cfzones "github.com/cloudflare/cloudflare-go/v6/zones"
+)
+
+var errProxyModeNotValid = stderrors.New("proxy mode is not valid")
+
+// ProxyMode controls whether and how Cloudflare proxies traffic for the
+// managed record, mirroring the `cloudflare_proxy` metadata dnscontrol
+// exposes: off, on (orange-clouded) or full (orange-clouded with the
+// zone-level "Full" SSL/TLS mode enforced).
+type ProxyMode string
+
+const (
+	ProxyOff  ProxyMode = "off"
+	ProxyOn   ProxyMode = "on"
+	ProxyFull ProxyMode = "full"
+)
+
+// Proxied reports whether records should be created with Cloudflare's proxy
+// (orange cloud) enabled, which is the case for both ProxyOn and ProxyFull.
+func (mode ProxyMode) Proxied() bool {
+	return mode == ProxyOn || mode == ProxyFull
+}
+
+func (mode ProxyMode) String() string {
+	return string(mode)
+}
+
+// ensureFullSSL sets the zone's SSL/TLS mode to "full", which is required
+// for Cloudflare's "full" proxy mode (ProxyFull) to serve traffic without
+// browser warnings: the origin must present a certificate Cloudflare trusts.
+// It only issues a write once per process, the first time it observes the
+// zone is not already set to "full", to avoid stomping on manual zone
+// configuration and writing on every Update poll forever.
+func (p *Provider) ensureFullSSL(ctx context.Context, cfClient *cf.Client, zoneID string) (err error) {
+	p.sslMutex.Lock()
+	defer p.sslMutex.Unlock()
+
+	if p.fullSSLEnsured {
+		return nil
+	}
+
+	setting, err := cfClient.Zones.Settings.Get(ctx, "ssl", cfzones.SettingGetParams{
+		ZoneID: cf.F(zoneID),
+	})
+	if err != nil {
+		return fmt.Errorf("getting zone SSL mode: %w", err)
+	}
+
+	if fmt.Sprint(setting.Value) != string(ProxyFull) {
+		_, err = cfClient.Zones.Settings.Edit(ctx, "ssl", cfzones.SettingEditParams{
+			ZoneID: cf.F(zoneID),
+			Value:  cf.F[any]("full"),
+		})
+		if err != nil {
+			return fmt.Errorf("setting zone SSL mode to full: %w", err)
+		}
+	}
+
+	p.fullSSLEnsured = true
+	return nil
+}
+
+func parseProxyMode(proxy string, proxied bool) (mode ProxyMode, err error) {
+	switch proxy {
+	case "":
+		// Backward-compatible alias: fall back to the legacy "proxied"
+		// boolean field when "proxy" is not set.
+		if proxied {
+			return ProxyOn, nil
+		}
+		return ProxyOff, nil
+	case string(ProxyOff), string(ProxyOn), string(ProxyFull):
+		return ProxyMode(proxy), nil
+	default:
+		return "", fmt.Errorf("%w: %q must be one of %q, %q or %q",
+			errProxyModeNotValid, proxy, ProxyOff, ProxyOn, ProxyFull)
+	}
+}
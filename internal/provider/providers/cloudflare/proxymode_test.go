@@ -0,0 +1,66 @@
+package cloudflare
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_parseProxyMode(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		proxy   string
+		proxied bool
+		mode    ProxyMode
+		err     error
+	}{
+		"empty falls back to proxied false": {
+			proxy: "",
+			mode:  ProxyOff,
+		},
+		"empty falls back to proxied true": {
+			proxy:   "",
+			proxied: true,
+			mode:    ProxyOn,
+		},
+		"off": {
+			proxy: "off",
+			mode:  ProxyOff,
+		},
+		"on": {
+			proxy: "on",
+			mode:  ProxyOn,
+		},
+		"full": {
+			proxy: "full",
+			mode:  ProxyFull,
+		},
+		"invalid": {
+			proxy: "partial",
+			err:   errProxyModeNotValid,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mode, err := parseProxyMode(testCase.proxy, testCase.proxied)
+
+			if testCase.err != nil {
+				if !errors.Is(err, testCase.err) {
+					t.Fatalf("expected error %q, got %q", testCase.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+			if mode != testCase.mode {
+				t.Fatalf("expected mode %q, got %q", testCase.mode, mode)
+			}
+		})
+	}
+}
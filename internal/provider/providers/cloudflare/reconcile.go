@@ -0,0 +1,208 @@
+package cloudflare
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/netip"
+
+	cf "github.com/cloudflare/cloudflare-go/v6"
+	cfdns "github.com/cloudflare/cloudflare-go/v6/dns"
+	"github.com/qdm12/ddns-updater/internal/provider"
+)
+
+// Mode controls how Update reconciles the DNS records matching the
+// provider's domain and IP version.
+type Mode string
+
+const (
+	// ModeSingle is the historical behaviour: exactly one matching record
+	// is expected, and Update fails if it finds more than one.
+	ModeSingle Mode = "single"
+	// ModeAppend ensures a record for the current IP exists alongside
+	// whatever other records already match, without touching them.
+	ModeAppend Mode = "append"
+	// ModeReplace fully reconciles the matching record set down to a
+	// single record holding the current IP, deleting any other record
+	// sharing the same name and type.
+	ModeReplace Mode = "replace"
+)
+
+var errModeNotValid = stderrors.New("mode is not valid")
+
+func parseMode(mode string) (parsed Mode, err error) {
+	switch Mode(mode) {
+	case "":
+		return ModeSingle, nil
+	case ModeSingle, ModeAppend, ModeReplace:
+		return Mode(mode), nil
+	default:
+		return "", fmt.Errorf("%w: %q must be one of %q, %q or %q",
+			errModeNotValid, mode, ModeSingle, ModeAppend, ModeReplace)
+	}
+}
+
+// RecordStatuses implements provider.MultiRecordProvider. It returns the
+// per-record state computed by the last call to reconcileRecords, and is
+// empty for providers running in ModeSingle.
+func (p *Provider) RecordStatuses() (statuses []provider.RecordStatus) {
+	p.statusMutex.Lock()
+	defer p.statusMutex.Unlock()
+	return p.recordStatuses
+}
+
+// recordAction is the action reconcilePlan decides to take for one existing
+// record.
+type recordAction int
+
+const (
+	actionKeep recordAction = iota
+	actionUpdate
+	actionDelete
+)
+
+// recordDecision is the action reconcilePlan decided to take for the
+// existing record at Index, plus the RecordStatuses entry it results in
+// (unset for actionDelete, which drops the record from the set entirely).
+type recordDecision struct {
+	Index  int
+	Action recordAction
+	Status provider.RecordStatus
+}
+
+// reconcilePlan decides, for mode and the current ip, what action to take on
+// each of the existing records whose content parses as an IP (contents is
+// indexed the same as the record list reconcileRecords fetched), and whether
+// a new record must be created because none of them could be kept or
+// updated to hold ip. In ModeReplace, the first record already holding ip
+// (or, failing that, the first stale record) is kept or updated to hold it,
+// and every other record is deleted; in ModeAppend, only a missing record is
+// created and every existing one is left untouched.
+func reconcilePlan(mode Mode, ip netip.Addr, contents []string) (decisions []recordDecision, createNew bool) {
+	matched := false
+	// In ModeReplace, a record already holding ip is kept in preference to
+	// updating a stale one, even when the stale record comes first.
+	holdsIP := false
+	for _, content := range contents {
+		if content == ip.String() {
+			holdsIP = true
+			break
+		}
+	}
+	decisions = make([]recordDecision, 0, len(contents))
+	for index, content := range contents {
+		recordIP, parseErr := netip.ParseAddr(content)
+		if parseErr != nil {
+			continue // not an IP we can reconcile, e.g. a CNAME-like result
+		}
+
+		switch {
+		case content == ip.String() && !(mode == ModeReplace && matched):
+			// Already holds ip: kept as is. In ModeReplace only the
+			// first such record is kept this way; any further one,
+			// including a duplicate already holding ip, is deleted
+			// below so the set converges to exactly one record.
+			matched = true
+			decisions = append(decisions, recordDecision{
+				Index: index, Action: actionKeep,
+				Status: provider.RecordStatus{IP: recordIP, Status: "up to date"},
+			})
+		case mode == ModeReplace && !matched && !holdsIP:
+			// First stale record, and no record holds ip yet: update it in place to hold ip
+			// instead of deleting and recreating it, reusing its ID
+			// and avoiding a window where the hostname resolves to
+			// nothing.
+			matched = true
+			decisions = append(decisions, recordDecision{
+				Index: index, Action: actionUpdate,
+				Status: provider.RecordStatus{IP: ip, Status: "up to date"},
+			})
+		case mode == ModeReplace:
+			decisions = append(decisions, recordDecision{Index: index, Action: actionDelete})
+		default:
+			decisions = append(decisions, recordDecision{
+				Index: index, Action: actionKeep,
+				Status: provider.RecordStatus{IP: recordIP, Status: "kept"},
+			})
+		}
+	}
+	return decisions, !matched
+}
+
+// reconcileRecords lists every record matching the provider's domain and
+// the record type for ip, then converges them towards a set containing
+// exactly one record holding ip, using the minimal New/Update/Delete calls
+// reconcilePlan decides are needed to get there. It records the resulting
+// per-record status for RecordStatuses.
+func (p *Provider) reconcileRecords(ctx context.Context, cfClient *cf.Client, zoneID string, ip netip.Addr) (err error) {
+	recordType := recordTypeForIP(ip)
+	records, err := cfClient.DNS.Records.List(ctx, cfdns.RecordListParams{
+		ZoneID: cf.F(zoneID),
+		Type:   cf.F(cfdns.RecordListParamsType(recordType)),
+		Name: cf.F(cfdns.RecordListParamsName{
+			Exact: cf.F(p.BuildDomainName()),
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("listing DNS records: %w", err)
+	}
+
+	contents := make([]string, len(records.Result))
+	for i, record := range records.Result {
+		contents[i] = record.Content
+	}
+	decisions, createNew := reconcilePlan(p.mode, ip, contents)
+
+	statuses := make([]provider.RecordStatus, 0, len(decisions)+1)
+	for _, decision := range decisions {
+		record := records.Result[decision.Index]
+		switch decision.Action {
+		case actionUpdate:
+			_, err = cfClient.DNS.Records.Update(ctx, record.ID, cfdns.RecordUpdateParams{
+				ZoneID: cf.F(zoneID),
+				Body: cfdns.ARecordParam{
+					Name:    cf.F(p.BuildDomainName()),
+					Type:    cf.F(recordType),
+					Content: cf.F(ip.String()),
+					TTL:     cf.F(cfdns.TTL(p.ttl)),
+					Proxied: cf.F(p.proxyMode.Proxied()),
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("updating record %s: %w", record.ID, err)
+			}
+			statuses = append(statuses, decision.Status)
+		case actionDelete:
+			_, err = cfClient.DNS.Records.Delete(ctx, record.ID, cfdns.RecordDeleteParams{
+				ZoneID: cf.F(zoneID),
+			})
+			if err != nil {
+				return fmt.Errorf("deleting stale record %s: %w", record.ID, err)
+			}
+		case actionKeep:
+			statuses = append(statuses, decision.Status)
+		}
+	}
+
+	if createNew {
+		_, err = cfClient.DNS.Records.New(ctx, cfdns.RecordNewParams{
+			ZoneID: cf.F(zoneID),
+			Body: cfdns.ARecordParam{
+				Name:    cf.F(p.BuildDomainName()),
+				Type:    cf.F(recordType),
+				Content: cf.F(ip.String()),
+				TTL:     cf.F(cfdns.TTL(p.ttl)),
+				Proxied: cf.F(p.proxyMode.Proxied()),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("creating record: %w", err)
+		}
+		statuses = append(statuses, provider.RecordStatus{IP: ip, Status: "up to date"})
+	}
+
+	p.statusMutex.Lock()
+	p.recordStatuses = statuses
+	p.statusMutex.Unlock()
+	return nil
+}
@@ -0,0 +1,155 @@
+package cloudflare
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider"
+)
+
+func Test_parseMode(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		mode string
+		want Mode
+		err  error
+	}{
+		"empty defaults to single": {
+			mode: "",
+			want: ModeSingle,
+		},
+		"single": {
+			mode: "single",
+			want: ModeSingle,
+		},
+		"append": {
+			mode: "append",
+			want: ModeAppend,
+		},
+		"replace": {
+			mode: "replace",
+			want: ModeReplace,
+		},
+		"invalid": {
+			mode: "merge",
+			err:  errModeNotValid,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mode, err := parseMode(testCase.mode)
+
+			if testCase.err != nil {
+				if !errors.Is(err, testCase.err) {
+					t.Fatalf("expected error %q, got %q", testCase.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %q", err)
+			}
+			if mode != testCase.want {
+				t.Fatalf("expected mode %q, got %q", testCase.want, mode)
+			}
+		})
+	}
+}
+
+func Test_reconcilePlan(t *testing.T) {
+	t.Parallel()
+
+	ip := netip.MustParseAddr("1.2.3.4")
+
+	testCases := map[string]struct {
+		mode      Mode
+		contents  []string
+		decisions []recordDecision
+		createNew bool
+	}{
+		"replace: no existing records creates one": {
+			mode:      ModeReplace,
+			contents:  nil,
+			decisions: []recordDecision{},
+			createNew: true,
+		},
+		"replace: already up to date is kept, nothing else to do": {
+			mode:     ModeReplace,
+			contents: []string{"1.2.3.4"},
+			decisions: []recordDecision{
+				{Index: 0, Action: actionKeep, Status: provider.RecordStatus{IP: ip, Status: "up to date"}},
+			},
+		},
+		"replace: stale record is updated in place, not deleted and recreated": {
+			mode:     ModeReplace,
+			contents: []string{"5.6.7.8"},
+			decisions: []recordDecision{
+				{Index: 0, Action: actionUpdate, Status: provider.RecordStatus{IP: ip, Status: "up to date"}},
+			},
+		},
+		"replace: duplicate up-to-date records converge to one, extra deleted": {
+			mode:     ModeReplace,
+			contents: []string{"1.2.3.4", "1.2.3.4"},
+			decisions: []recordDecision{
+				{Index: 0, Action: actionKeep, Status: provider.RecordStatus{IP: ip, Status: "up to date"}},
+				{Index: 1, Action: actionDelete},
+			},
+		},
+		"replace: first stale record updated, remaining stale records deleted": {
+			mode:     ModeReplace,
+			contents: []string{"5.6.7.8", "9.9.9.9"},
+			decisions: []recordDecision{
+				{Index: 0, Action: actionUpdate, Status: provider.RecordStatus{IP: ip, Status: "up to date"}},
+				{Index: 1, Action: actionDelete},
+			},
+		},
+		"append: existing records of any content are left untouched": {
+			mode:     ModeAppend,
+			contents: []string{"5.6.7.8"},
+			decisions: []recordDecision{
+				{Index: 0, Action: actionKeep, Status: provider.RecordStatus{IP: netip.MustParseAddr("5.6.7.8"), Status: "kept"}},
+			},
+			createNew: true,
+		},
+		"append: a record already matching is kept, no new record created": {
+			mode:     ModeAppend,
+			contents: []string{"1.2.3.4"},
+			decisions: []recordDecision{
+				{Index: 0, Action: actionKeep, Status: provider.RecordStatus{IP: ip, Status: "up to date"}},
+			},
+		},
+		"non-IP record content is skipped": {
+			mode:      ModeReplace,
+			contents:  []string{"not-an-ip"},
+			decisions: []recordDecision{},
+			createNew: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			decisions, createNew := reconcilePlan(testCase.mode, ip, testCase.contents)
+
+			if createNew != testCase.createNew {
+				t.Fatalf("expected createNew %v, got %v", testCase.createNew, createNew)
+			}
+			if len(decisions) != len(testCase.decisions) {
+				t.Fatalf("expected decisions %+v, got %+v", testCase.decisions, decisions)
+			}
+			for i, decision := range decisions {
+				if decision != testCase.decisions[i] {
+					t.Fatalf("expected decisions %+v, got %+v", testCase.decisions, decisions)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package provider
+
+import "net/netip"
+
+// RecordStatus describes the state of a single DNS record a provider
+// manages as part of a larger reconciled set, such as one of several
+// round-robin A records sharing the same name.
+type RecordStatus struct {
+	IP     netip.Addr
+	Status string
+}
+
+// MultiRecordProvider is implemented by providers that may manage more than
+// one DNS record for the same domain, so callers can list each underlying
+// record individually instead of collapsing them into a single row.
+type MultiRecordProvider interface {
+	RecordStatuses() (statuses []RecordStatus)
+}
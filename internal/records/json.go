@@ -6,6 +6,7 @@ import (
 
 	"github.com/qdm12/ddns-updater/internal/constants"
 	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/provider"
 )
 
 func (r *Record) JSON(now time.Time) models.Stat {
@@ -33,5 +34,13 @@ func (r *Record) JSON(now time.Time) models.Stat {
 			message,
 			time.Since(r.Time).Round(time.Second).String()+" ago")
 	}
+	if multiRecordProvider, ok := r.Provider.(provider.MultiRecordProvider); ok {
+		for _, status := range multiRecordProvider.RecordStatuses() {
+			row.Records = append(row.Records, models.StatRecord{
+				IP:     status.IP,
+				Status: status.Status,
+			})
+		}
+	}
 	return row
 }
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/internal/provider"
+)
+
+var errNoACMEProvider = stderrors.New("no ACME-capable provider configured for this domain")
+
+// acmeChallengeRequest is the JSON body sent by certbot/lego/cert-manager
+// DNS-01 webhook hooks to the present and cleanup endpoints below.
+type acmeChallengeRequest struct {
+	FQDN  string `json:"fqdn"`
+	Value string `json:"value"`
+}
+
+// acmePresent handles POST /acme/present, creating the TXT record an ACME
+// DNS-01 challenge expects for the domain matching the request's fqdn.
+func (h *handlers) acmePresent(w http.ResponseWriter, r *http.Request) {
+	h.handleACMEChallenge(w, r, func(p provider.ACMETXTProvider, fqdn, value string) error {
+		return p.PresentTXT(r.Context(), fqdn, value)
+	})
+}
+
+// acmeCleanup handles POST /acme/cleanup, removing the TXT record created
+// by a previous call to acmePresent for the same fqdn and value.
+func (h *handlers) acmeCleanup(w http.ResponseWriter, r *http.Request) {
+	h.handleACMEChallenge(w, r, func(p provider.ACMETXTProvider, fqdn, value string) error {
+		return p.CleanupTXT(r.Context(), fqdn, value)
+	})
+}
+
+// handleACMEChallenge decodes the {fqdn, value} body shared by the present
+// and cleanup webhooks, locates the configured ACME-capable provider for
+// that fqdn and runs action against it.
+func (h *handlers) handleACMEChallenge(w http.ResponseWriter, r *http.Request,
+	action func(p provider.ACMETXTProvider, fqdn, value string) error,
+) {
+	var request acmeChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		httpError(w, http.StatusBadRequest, "decoding request body: "+err.Error())
+		return
+	}
+	if request.FQDN == "" || request.Value == "" {
+		httpError(w, http.StatusBadRequest, "fqdn and value fields are both required")
+		return
+	}
+
+	acmeProvider, err := h.findACMEProvider(request.FQDN)
+	if err != nil {
+		httpError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err := action(acmeProvider, request.FQDN, request.Value); err != nil {
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// findACMEProvider returns the ACME-capable provider configured for the
+// domain fqdn belongs to, matching it by stripping the `_acme-challenge.`
+// label and comparing against each configured provider's domain name.
+func (h *handlers) findACMEProvider(fqdn string) (acmeProvider provider.ACMETXTProvider, err error) {
+	name := strings.TrimSuffix(fqdn, ".")
+	name = strings.TrimPrefix(name, "_acme-challenge.")
+
+	for _, record := range h.db.SelectAll() {
+		domain := record.Provider.BuildDomainName()
+		if domain != name && !strings.HasSuffix(name, "."+domain) {
+			continue
+		}
+		if acmeProvider, ok := record.Provider.(provider.ACMETXTProvider); ok {
+			return acmeProvider, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", errNoACMEProvider, fqdn)
+}
@@ -0,0 +1,13 @@
+package server
+
+import "net/http"
+
+// routes builds the HTTP handler serving every endpoint this package
+// exposes, wiring each handler method to its path.
+func (h *handlers) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", h.status)
+	mux.HandleFunc("/acme/present", h.acmePresent)
+	mux.HandleFunc("/acme/cleanup", h.acmeCleanup)
+	return mux
+}